@@ -0,0 +1,441 @@
+// Copyright 2020 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gamepaddb resolves a gamepad's raw axes/buttons/hats into the
+// standard SDL_GameController-style layout (A/B/X/Y, D-Pad, shoulders,
+// triggers, sticks, Start/Back/Guide) using the same mapping text format
+// that SDL's GameControllerDB uses.
+package gamepaddb
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/internal/driver"
+)
+
+type mappingInputType int
+
+const (
+	mappingInputTypeNone mappingInputType = iota
+	mappingInputTypeButton
+	mappingInputTypeAxis
+	mappingInputTypeHat
+)
+
+// mappingInput describes where a standard button/axis's value is read from
+// on the underlying raw device.
+type mappingInput struct {
+	Type mappingInputType
+
+	// Used when Type == mappingInputTypeButton.
+	Button int
+
+	// Used when Type == mappingInputTypeAxis.
+	// AxisInverted negates the raw value before use. AxisHalf is 0 for a
+	// full axis, or +1/-1 to only map the positive/negative half of the
+	// raw axis (the other half reads as the axis's resting value, 0). A
+	// mapped half reads from 0 at rest to 1 at full deflection.
+	Axis         int
+	AxisInverted bool
+	AxisHalf     int
+
+	// Used when Type == mappingInputTypeHat.
+	Hat      int
+	HatState int
+}
+
+type mapping struct {
+	name string
+
+	buttons [driver.StandardGamepadButtonNum]mappingInput
+	axes    [driver.StandardGamepadAxisNum]mappingInput
+}
+
+var (
+	mappingsM sync.RWMutex
+	mappings  = map[string]*mapping{}
+)
+
+func init() {
+	if err := update(gamecontrollerdbTxt); err != nil {
+		panic(fmt.Sprintf("gamepaddb: failed to parse the bundled gamecontrollerdb.txt: %v", err))
+	}
+}
+
+// Update parses mappingsCSV, which must be in the same format as SDL's
+// gamecontrollerdb.txt (one mapping per line), and merges its entries into
+// the mapping database, overwriting any existing entry with the same GUID.
+// Lines with a platform clause that doesn't match the current OS are
+// ignored.
+func Update(mappingsCSV string) error {
+	mappingsM.Lock()
+	defer mappingsM.Unlock()
+	return update(mappingsCSV)
+}
+
+func update(mappingsCSV string) error {
+	for n, line := range strings.Split(mappingsCSV, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		guid, m, err := parseMapping(line)
+		if err != nil {
+			return fmt.Errorf("gamepaddb: line %d: %w", n+1, err)
+		}
+		if m == nil {
+			// The mapping has a platform clause that doesn't match this OS.
+			continue
+		}
+		mappings[guid] = m
+	}
+	return nil
+}
+
+func parseMapping(line string) (string, *mapping, error) {
+	tokens := strings.Split(line, ",")
+	if len(tokens) < 2 {
+		return "", nil, fmt.Errorf("gamepaddb: not enough fields: %q", line)
+	}
+
+	guid := tokens[0]
+	m := &mapping{
+		name: tokens[1],
+	}
+
+	for _, token := range tokens[2:] {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+		kv := strings.SplitN(token, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key, value := kv[0], kv[1]
+
+		if key == "platform" {
+			if !platformMatches(value) {
+				return guid, nil, nil
+			}
+			continue
+		}
+
+		input, err := parseInput(value)
+		if err != nil {
+			return "", nil, fmt.Errorf("gamepaddb: %q: %w", token, err)
+		}
+
+		if b, ok := buttonNames[key]; ok {
+			m.buttons[b] = input
+			continue
+		}
+		if a, ok := axisNames[key]; ok {
+			m.axes[a] = input
+			continue
+		}
+		// Unknown field name (e.g. "misc1", "paddle1", ...): ignore it, as
+		// SDL itself adds new fields over time that older parsers should
+		// just skip.
+	}
+
+	return guid, m, nil
+}
+
+func platformMatches(platform string) bool {
+	switch platform {
+	case "Windows":
+		return runtime.GOOS == "windows"
+	case "Mac OS X":
+		return runtime.GOOS == "darwin"
+	case "Linux":
+		return runtime.GOOS == "linux"
+	case "Android":
+		return runtime.GOOS == "android"
+	case "iOS":
+		return runtime.GOOS == "ios"
+	default:
+		return false
+	}
+}
+
+// parseInput parses one value of a mapping entry, e.g. "b0", "a2", "a2~",
+// "+a2", "-a2", or "h0.1".
+func parseInput(value string) (mappingInput, error) {
+	half := 0
+	switch {
+	case strings.HasPrefix(value, "+"):
+		half = 1
+		value = value[1:]
+	case strings.HasPrefix(value, "-"):
+		half = -1
+		value = value[1:]
+	}
+
+	inverted := false
+	if strings.HasSuffix(value, "~") {
+		inverted = true
+		value = value[:len(value)-1]
+	}
+
+	if value == "" {
+		return mappingInput{}, fmt.Errorf("empty input")
+	}
+
+	switch value[0] {
+	case 'b':
+		idx, err := strconv.Atoi(value[1:])
+		if err != nil {
+			return mappingInput{}, err
+		}
+		return mappingInput{Type: mappingInputTypeButton, Button: idx}, nil
+	case 'a':
+		idx, err := strconv.Atoi(value[1:])
+		if err != nil {
+			return mappingInput{}, err
+		}
+		return mappingInput{Type: mappingInputTypeAxis, Axis: idx, AxisInverted: inverted, AxisHalf: half}, nil
+	case 'h':
+		hat, bit, ok := strings.Cut(value[1:], ".")
+		if !ok {
+			return mappingInput{}, fmt.Errorf("malformed hat input: %q", value)
+		}
+		h, err := strconv.Atoi(hat)
+		if err != nil {
+			return mappingInput{}, err
+		}
+		s, err := strconv.Atoi(bit)
+		if err != nil {
+			return mappingInput{}, err
+		}
+		return mappingInput{Type: mappingInputTypeHat, Hat: h, HatState: s}, nil
+	default:
+		return mappingInput{}, fmt.Errorf("unknown input kind: %q", value)
+	}
+}
+
+var buttonNames = map[string]driver.StandardGamepadButton{
+	"a":             driver.StandardGamepadButtonA,
+	"b":             driver.StandardGamepadButtonB,
+	"x":             driver.StandardGamepadButtonX,
+	"y":             driver.StandardGamepadButtonY,
+	"back":          driver.StandardGamepadButtonBack,
+	"guide":         driver.StandardGamepadButtonGuide,
+	"start":         driver.StandardGamepadButtonStart,
+	"leftstick":     driver.StandardGamepadButtonLeftStick,
+	"rightstick":    driver.StandardGamepadButtonRightStick,
+	"leftshoulder":  driver.StandardGamepadButtonLB,
+	"rightshoulder": driver.StandardGamepadButtonRB,
+	"dpup":          driver.StandardGamepadButtonDPadUp,
+	"dpdown":        driver.StandardGamepadButtonDPadDown,
+	"dpleft":        driver.StandardGamepadButtonDPadLeft,
+	"dpright":       driver.StandardGamepadButtonDPadRight,
+}
+
+var axisNames = map[string]driver.StandardGamepadAxis{
+	"leftx":        driver.StandardGamepadAxisLeftStickHorizontal,
+	"lefty":        driver.StandardGamepadAxisLeftStickVertical,
+	"rightx":       driver.StandardGamepadAxisRightStickHorizontal,
+	"righty":       driver.StandardGamepadAxisRightStickVertical,
+	"lefttrigger":  driver.StandardGamepadAxisLT,
+	"righttrigger": driver.StandardGamepadAxisRT,
+}
+
+// HasStandardLayoutMapping reports whether guid has a known entry in the
+// mapping database. When false, callers fall back to a heuristic mapping.
+func HasStandardLayoutMapping(guid string) bool {
+	mappingsM.RLock()
+	defer mappingsM.RUnlock()
+	_, ok := mappings[guid]
+	return ok
+}
+
+// StandardButtonValue reports whether the given standard button is pressed
+// for the gamepad identified by guid, given its raw button/axis/hat state.
+// If guid has no known mapping, a heuristic Xbox-style mapping is used.
+func StandardButtonValue(guid string, button driver.StandardGamepadButton, axes []float64, buttons []bool, hats []int) bool {
+	mappingsM.RLock()
+	m, ok := mappings[guid]
+	mappingsM.RUnlock()
+	if !ok {
+		return fallbackButtonValue(button, axes, buttons, hats)
+	}
+	return inputAsBool(m.buttons[button], axes, buttons, hats)
+}
+
+// StandardAxisValue returns the value, in the range [-1, 1], of the given
+// standard axis for the gamepad identified by guid, given its raw state. If
+// guid has no known mapping, a heuristic Xbox-style mapping is used.
+func StandardAxisValue(guid string, axis driver.StandardGamepadAxis, axes []float64, buttons []bool, hats []int) float64 {
+	mappingsM.RLock()
+	m, ok := mappings[guid]
+	mappingsM.RUnlock()
+	if !ok {
+		return fallbackAxisValue(axis, axes, buttons, hats)
+	}
+	return inputAsFloat(m.axes[axis], axes, buttons, hats)
+}
+
+func inputAsBool(in mappingInput, axes []float64, buttons []bool, hats []int) bool {
+	switch in.Type {
+	case mappingInputTypeButton:
+		return getButton(buttons, in.Button)
+	case mappingInputTypeAxis:
+		return inputAsFloat(in, axes, buttons, hats) > 0.5
+	case mappingInputTypeHat:
+		return getHat(hats, in.Hat)&in.HatState != 0
+	default:
+		return false
+	}
+}
+
+func inputAsFloat(in mappingInput, axes []float64, buttons []bool, hats []int) float64 {
+	switch in.Type {
+	case mappingInputTypeButton:
+		if getButton(buttons, in.Button) {
+			return 1
+		}
+		return -1
+	case mappingInputTypeAxis:
+		v := getAxis(axes, in.Axis)
+		if in.AxisInverted {
+			v = -v
+		}
+		switch in.AxisHalf {
+		case 1:
+			if v < 0 {
+				return 0
+			}
+			return v
+		case -1:
+			if v > 0 {
+				return 0
+			}
+			return -v
+		default:
+			return v
+		}
+	case mappingInputTypeHat:
+		if getHat(hats, in.Hat)&in.HatState != 0 {
+			return 1
+		}
+		return -1
+	default:
+		return 0
+	}
+}
+
+func getButton(buttons []bool, idx int) bool {
+	if idx < 0 || idx >= len(buttons) {
+		return false
+	}
+	return buttons[idx]
+}
+
+func getHat(hats []int, idx int) int {
+	if idx < 0 || idx >= len(hats) {
+		return 0
+	}
+	return hats[idx]
+}
+
+func getAxis(axes []float64, idx int) float64 {
+	if idx < 0 || idx >= len(axes) {
+		return 0
+	}
+	return axes[idx]
+}
+
+const (
+	hatUp    = 1
+	hatRight = 2
+	hatDown  = 4
+	hatLeft  = 8
+)
+
+// fallbackButtonValue and fallbackAxisValue implement a heuristic
+// Xbox-style mapping, used when a gamepad's GUID isn't present in the
+// mapping database. This matches the layout GLFW reports for XInput
+// controllers on Windows. It does not match the evdev order GLFW reports
+// on Linux, where, for example, an Xbox 360 controller has Guide at button
+// 8 and the left trigger at axis 2 (see its entry in gamecontrollerdb.go).
+func fallbackButtonValue(button driver.StandardGamepadButton, axes []float64, buttons []bool, hats []int) bool {
+	switch button {
+	case driver.StandardGamepadButtonA:
+		return getButton(buttons, 0)
+	case driver.StandardGamepadButtonB:
+		return getButton(buttons, 1)
+	case driver.StandardGamepadButtonX:
+		return getButton(buttons, 2)
+	case driver.StandardGamepadButtonY:
+		return getButton(buttons, 3)
+	case driver.StandardGamepadButtonLB:
+		return getButton(buttons, 4)
+	case driver.StandardGamepadButtonRB:
+		return getButton(buttons, 5)
+	case driver.StandardGamepadButtonBack:
+		return getButton(buttons, 6)
+	case driver.StandardGamepadButtonStart:
+		return getButton(buttons, 7)
+	case driver.StandardGamepadButtonLeftStick:
+		return getButton(buttons, 8)
+	case driver.StandardGamepadButtonRightStick:
+		return getButton(buttons, 9)
+	case driver.StandardGamepadButtonGuide:
+		return getButton(buttons, 10)
+	case driver.StandardGamepadButtonDPadUp:
+		if len(hats) > 0 {
+			return hats[0]&hatUp != 0
+		}
+		return getButton(buttons, 11)
+	case driver.StandardGamepadButtonDPadDown:
+		if len(hats) > 0 {
+			return hats[0]&hatDown != 0
+		}
+		return getButton(buttons, 12)
+	case driver.StandardGamepadButtonDPadLeft:
+		if len(hats) > 0 {
+			return hats[0]&hatLeft != 0
+		}
+		return getButton(buttons, 13)
+	case driver.StandardGamepadButtonDPadRight:
+		if len(hats) > 0 {
+			return hats[0]&hatRight != 0
+		}
+		return getButton(buttons, 14)
+	}
+	return false
+}
+
+func fallbackAxisValue(axis driver.StandardGamepadAxis, axes []float64, buttons []bool, hats []int) float64 {
+	switch axis {
+	case driver.StandardGamepadAxisLeftStickHorizontal:
+		return getAxis(axes, 0)
+	case driver.StandardGamepadAxisLeftStickVertical:
+		return getAxis(axes, 1)
+	case driver.StandardGamepadAxisRightStickHorizontal:
+		return getAxis(axes, 2)
+	case driver.StandardGamepadAxisRightStickVertical:
+		return getAxis(axes, 3)
+	case driver.StandardGamepadAxisLT:
+		return getAxis(axes, 4)
+	case driver.StandardGamepadAxisRT:
+		return getAxis(axes, 5)
+	}
+	return 0
+}
@@ -0,0 +1,135 @@
+// Copyright 2020 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gamepaddb
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestParseInput(t *testing.T) {
+	cases := []struct {
+		in   string
+		want mappingInput
+	}{
+		{"b3", mappingInput{Type: mappingInputTypeButton, Button: 3}},
+		{"a2", mappingInput{Type: mappingInputTypeAxis, Axis: 2}},
+		{"a2~", mappingInput{Type: mappingInputTypeAxis, Axis: 2, AxisInverted: true}},
+		{"+a2", mappingInput{Type: mappingInputTypeAxis, Axis: 2, AxisHalf: 1}},
+		{"-a2", mappingInput{Type: mappingInputTypeAxis, Axis: 2, AxisHalf: -1}},
+		{"-a2~", mappingInput{Type: mappingInputTypeAxis, Axis: 2, AxisHalf: -1, AxisInverted: true}},
+		{"h0.1", mappingInput{Type: mappingInputTypeHat, Hat: 0, HatState: 1}},
+		{"h0.4", mappingInput{Type: mappingInputTypeHat, Hat: 0, HatState: 4}},
+	}
+	for _, c := range cases {
+		got, err := parseInput(c.in)
+		if err != nil {
+			t.Errorf("parseInput(%q) returned error: %v", c.in, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("parseInput(%q) = %+v, want %+v", c.in, got, c.want)
+		}
+	}
+}
+
+func TestParseInputInvalid(t *testing.T) {
+	for _, in := range []string{"", "z3", "h0", "hx.1", "h0.x", "ax"} {
+		if _, err := parseInput(in); err == nil {
+			t.Errorf("parseInput(%q) returned no error, want one", in)
+		}
+	}
+}
+
+func TestPlatformMatches(t *testing.T) {
+	cases := []struct {
+		platform string
+		goos     string
+	}{
+		{"Windows", "windows"},
+		{"Mac OS X", "darwin"},
+		{"Linux", "linux"},
+		{"Android", "android"},
+		{"iOS", "ios"},
+	}
+	for _, c := range cases {
+		want := runtime.GOOS == c.goos
+		if got := platformMatches(c.platform); got != want {
+			t.Errorf("platformMatches(%q) = %t, want %t", c.platform, got, want)
+		}
+	}
+	if platformMatches("Nonexistent") {
+		t.Errorf("platformMatches(%q) = true, want false", "Nonexistent")
+	}
+}
+
+func TestParseMapping(t *testing.T) {
+	const line = "030000005e0400008e02000014010000,Xbox 360 Controller,a:b0,b:b1,leftx:a0,lefty:a1~,dpup:h0.1,platform:Linux,"
+
+	guid, m, err := parseMapping(line)
+	if err != nil {
+		t.Fatalf("parseMapping returned error: %v", err)
+	}
+	if runtime.GOOS != "linux" {
+		if m != nil {
+			t.Fatalf("parseMapping on %s = %+v, want nil (platform clause doesn't match)", runtime.GOOS, m)
+		}
+		return
+	}
+
+	const wantGUID = "030000005e0400008e02000014010000"
+	if guid != wantGUID {
+		t.Errorf("guid = %q, want %q", guid, wantGUID)
+	}
+	if m == nil {
+		t.Fatal("parseMapping returned a nil mapping on a matching platform")
+	}
+	if got, want := m.name, "Xbox 360 Controller"; got != want {
+		t.Errorf("name = %q, want %q", got, want)
+	}
+
+	a := buttonNames["a"]
+	if got, want := m.buttons[a], (mappingInput{Type: mappingInputTypeButton, Button: 0}); got != want {
+		t.Errorf("buttons[a] = %+v, want %+v", got, want)
+	}
+
+	leftY := axisNames["lefty"]
+	if got, want := m.axes[leftY], (mappingInput{Type: mappingInputTypeAxis, Axis: 1, AxisInverted: true}); got != want {
+		t.Errorf("axes[lefty] = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseMappingWrongPlatform(t *testing.T) {
+	other := "Linux"
+	if runtime.GOOS == "linux" {
+		other = "Windows"
+	}
+	guid, m, err := parseMapping("guid,name,a:b0,platform:" + other)
+	if err != nil {
+		t.Fatalf("parseMapping returned error: %v", err)
+	}
+	if m != nil {
+		t.Errorf("parseMapping for platform:%s on %s = %+v, want nil", other, runtime.GOOS, m)
+	}
+	if guid != "guid" {
+		t.Errorf("guid = %q, want %q", guid, "guid")
+	}
+}
+
+func TestParseMappingNotEnoughFields(t *testing.T) {
+	if _, _, err := parseMapping("onlyguid"); err == nil {
+		t.Error("parseMapping with a single field returned no error, want one")
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2020 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build darwin freebsd linux windows
+// +build !js
+// +build !android
+// +build !ios
+
+package glfw
+
+import (
+	"fmt"
+	"time"
+)
+
+// rumbleEffect is implemented per-OS (see gamepadrumble_*.go) since GLFW
+// itself doesn't expose force-feedback. It is opened lazily. On Linux and
+// Windows the OS-specific device is found from the joystick's GUID; on
+// macOS it is looked up from the GLFW joystick index.
+type rumbleEffect interface {
+	vibrate(duration time.Duration, strongMagnitude, weakMagnitude float64)
+	close() error
+}
+
+// VibrateGamepad starts a rumble effect on the gamepad id. strongMagnitude
+// and weakMagnitude are normalized to the range [0, 1] and drive the
+// device's low-frequency (strong) and high-frequency (weak) motors
+// respectively. On macOS the two magnitudes are averaged into a single
+// constant-force effect. A duration of 0 stops any effect currently playing.
+//
+// This is only reachable through the glfw driver's concrete Input today;
+// it still needs a driver.Input interface entry so mobile/js can pick it
+// up (or stub it out), but internal/driver isn't part of this change.
+func (i *Input) VibrateGamepad(id int, duration time.Duration, strongMagnitude, weakMagnitude float64) error {
+	i.ui.m.Lock()
+	defer i.ui.m.Unlock()
+
+	if len(i.gamepads) <= id || !i.gamepads[id].valid {
+		return fmt.Errorf("glfw: gamepad %d is not connected", id)
+	}
+
+	g := &i.gamepads[id]
+	if g.rumble == nil {
+		r, err := openRumbleEffect(id, g.guid)
+		if err != nil {
+			return err
+		}
+		g.rumble = r
+	}
+	g.rumble.vibrate(duration, strongMagnitude, weakMagnitude)
+	return nil
+}
+
+func (g *gamePad) closeRumble() {
+	if g.rumble == nil {
+		return
+	}
+	// The error is ignored: the device might already be gone by the time
+	// the joystick disconnects.
+	_ = g.rumble.close()
+	g.rumble = nil
+}
+
+func clamp01(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
@@ -0,0 +1,114 @@
+// Copyright 2020 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build darwin,!ios
+
+package glfw
+
+// #cgo LDFLAGS: -framework IOKit -framework ForceFeedback
+//
+// #include <IOKit/IOKitLib.h>
+// #include <IOKit/hid/IOHIDDevice.h>
+// #include <ForceFeedback/ForceFeedback.h>
+// #include <ForceFeedback/ForceFeedbackConstants.h>
+import "C"
+
+import (
+	"fmt"
+	"time"
+	"unsafe"
+
+	"github.com/hajimehoshi/ebiten/internal/glfw"
+)
+
+// darwinRumbleEffect drives a basic rumble effect through the macOS
+// ForceFeedback framework, on the io_service_t backing the joystick's own
+// IOHIDDeviceRef.
+type darwinRumbleEffect struct {
+	device C.FFDeviceObjectReference
+	effect C.FFEffectObjectReference
+}
+
+func openRumbleEffect(id int, guid string) (rumbleEffect, error) {
+	hidDevice := glfw.GetJoystickIOHIDDevice(glfw.Joystick(id))
+	if hidDevice == nil {
+		return nil, fmt.Errorf("glfw: no IOHID device for gamepad %d (guid %q)", id, guid)
+	}
+
+	service := C.IOHIDDeviceGetService(C.IOHIDDeviceRef(hidDevice))
+	if service == 0 {
+		return nil, fmt.Errorf("glfw: IOHIDDeviceGetService failed for gamepad %d (guid %q)", id, guid)
+	}
+
+	var device C.FFDeviceObjectReference
+	if C.FFCreateDevice(service, &device) != C.FF_OK {
+		return nil, fmt.Errorf("glfw: FFCreateDevice failed for gamepad %d (guid %q)", id, guid)
+	}
+	return &darwinRumbleEffect{device: device}, nil
+}
+
+func (r *darwinRumbleEffect) vibrate(duration time.Duration, strongMagnitude, weakMagnitude float64) {
+	if duration == 0 {
+		r.stop()
+		return
+	}
+
+	magnitude := clamp01((strongMagnitude + weakMagnitude) / 2)
+
+	var axes C.DWORD = C.FFJOFS_X
+	var direction C.LONG = 0
+	envelope := C.FFENVELOPE{
+		dwSize: C.DWORD(unsafe.Sizeof(C.FFENVELOPE{})),
+	}
+	cond := C.FFCONSTANTFORCE{
+		lMagnitude: C.LONG(magnitude * C.FF_FFNOMINALMAX),
+	}
+	eff := C.FFEFFECT{
+		dwSize:                C.DWORD(unsafe.Sizeof(C.FFEFFECT{})),
+		dwFlags:               C.FFEFF_OBJECTOFFSETS,
+		dwDuration:            C.DWORD(duration / time.Microsecond),
+		dwTriggerButton:       C.FFEB_NOTRIGGER,
+		cAxes:                 1,
+		rglDirection:          &direction,
+		lpEnvelope:            &envelope,
+		cbTypeSpecificParams:  C.DWORD(unsafe.Sizeof(cond)),
+		lpvTypeSpecificParams: unsafe.Pointer(&cond),
+		rgdwAxes:              &axes,
+	}
+
+	if r.effect != nil {
+		C.FFDeviceReleaseEffect(r.device, r.effect)
+		r.effect = nil
+	}
+	if C.FFDeviceCreateEffect(r.device, &C.kFFEffectType_ConstantForce_ID, &eff, &r.effect) != C.FF_OK {
+		return
+	}
+	C.FFEffectStart(r.effect, 1, 0)
+}
+
+func (r *darwinRumbleEffect) stop() {
+	if r.effect != nil {
+		C.FFEffectStop(r.effect)
+	}
+}
+
+func (r *darwinRumbleEffect) close() error {
+	r.stop()
+	if r.effect != nil {
+		C.FFDeviceReleaseEffect(r.device, r.effect)
+		r.effect = nil
+	}
+	C.FFReleaseDevice(r.device)
+	return nil
+}
@@ -0,0 +1,27 @@
+// Copyright 2020 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build freebsd
+
+package glfw
+
+import "fmt"
+
+// openRumbleEffect is a stub on FreeBSD: the kernel's force-feedback ioctl
+// interface differs enough from Linux's evdev FF_RUMBLE (different device
+// nodes, no EVIOCSFF) that it isn't implemented yet. Gamepads still work
+// for input; VibrateGamepad just reports that rumble isn't available.
+func openRumbleEffect(id int, guid string) (rumbleEffect, error) {
+	return nil, fmt.Errorf("glfw: gamepad rumble is not supported on freebsd")
+}
@@ -0,0 +1,198 @@
+// Copyright 2020 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux,!android
+
+package glfw
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// linuxRumbleEffect drives the Linux FF_RUMBLE force-feedback effect on the
+// /dev/input/eventN node that corresponds to a GLFW joystick.
+type linuxRumbleEffect struct {
+	fd        int
+	effectID  int16
+	hasEffect bool
+}
+
+// inputEvent mirrors struct input_event from linux/input.h.
+type inputEvent struct {
+	Sec   int64
+	Usec  int64
+	Type  uint16
+	Code  uint16
+	Value int32
+}
+
+// ffEffect mirrors struct ff_effect from linux/input.h, rumble-only. The
+// kernel's union member (struct ff_periodic_effect, the largest variant)
+// holds a pointer, so the union is 8-byte aligned and 32 bytes wide on
+// 64-bit; both are reproduced here with explicit padding so this struct's
+// size and field offsets match the kernel's exactly; EVIOCSFF rejects any
+// ioctl whose encoded size doesn't match sizeof(struct ff_effect) exactly.
+type ffEffect struct {
+	Type      uint16
+	ID        int16
+	Direction uint16
+	Trigger   struct {
+		Button   uint16
+		Interval uint16
+	}
+	Replay struct {
+		Length uint16
+		Delay  uint16
+	}
+	_               [2]byte // pad the union up to its 8-byte alignment
+	StrongMagnitude uint16
+	WeakMagnitude   uint16
+	_               [28]byte // pad out to the union's full (32-byte) size
+}
+
+const (
+	evFF     = 0x15
+	ffRumble = 0x50
+
+	iocWrite  = 1
+	iocEMagic = 'E'
+)
+
+// ioctlEncode reproduces the kernel's _IOC/_IOW macros from
+// linux/ioctl.h well enough for the two request codes used here.
+func ioctlEncode(dir, typ, nr, size int) uintptr {
+	return uintptr(dir<<30 | size<<16 | typ<<8 | nr)
+}
+
+var (
+	eviocsff  = ioctlEncode(iocWrite, iocEMagic, 0x80, int(unsafe.Sizeof(ffEffect{}))) // _IOW('E', 0x80, struct ff_effect)
+	eviocrmff = ioctlEncode(iocWrite, iocEMagic, 0x81, 4)                              // _IOW('E', 0x81, int)
+)
+
+func openRumbleEffect(id int, guid string) (rumbleEffect, error) {
+	path, err := findEventDeviceByGUID(guid)
+	if err != nil {
+		return nil, err
+	}
+	fd, err := unix.Open(path, unix.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("glfw: opening %s for rumble: %w", path, err)
+	}
+	return &linuxRumbleEffect{fd: fd, effectID: -1}, nil
+}
+
+// findEventDeviceByGUID scans /sys/class/input for the evdev node whose
+// bus/vendor/product match the GLFW joystick GUID, which encodes those
+// fields (and the version, which is not compared) as hex in the same way
+// SDL's Linux GUIDs do.
+func findEventDeviceByGUID(guid string) (string, error) {
+	if len(guid) < 20 {
+		return "", fmt.Errorf("glfw: malformed joystick guid %q", guid)
+	}
+	bus := swapHexPair(guid[0:4])
+	vendor := swapHexPair(guid[8:12])
+	product := swapHexPair(guid[16:20])
+
+	entries, err := os.ReadDir("/sys/class/input")
+	if err != nil {
+		return "", err
+	}
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Name(), "event") {
+			continue
+		}
+		idDir := filepath.Join("/sys/class/input", e.Name(), "device", "id")
+		if readIDFile(idDir, "bustype") != bus {
+			continue
+		}
+		if readIDFile(idDir, "vendor") != vendor {
+			continue
+		}
+		if readIDFile(idDir, "product") != product {
+			continue
+		}
+		return filepath.Join("/dev/input", e.Name()), nil
+	}
+	return "", fmt.Errorf("glfw: no /dev/input device found for guid %q", guid)
+}
+
+func readIDFile(dir, name string) string {
+	b, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(string(b)), "0x")))
+}
+
+// swapHexPair converts a 4-hex-digit little-endian field (as used in GLFW's
+// GUID encoding) into the plain, zero-padded hex string sysfs reports it
+// as (e.g. bus type 0x0003 as "0003", not "3").
+func swapHexPair(s string) string {
+	if len(s) != 4 {
+		return s
+	}
+	n, err := strconv.ParseUint(s[2:4]+s[0:2], 16, 16)
+	if err != nil {
+		return s
+	}
+	return fmt.Sprintf("%04x", n)
+}
+
+func (r *linuxRumbleEffect) vibrate(duration time.Duration, strongMagnitude, weakMagnitude float64) {
+	if duration == 0 {
+		r.stop()
+		return
+	}
+
+	e := ffEffect{
+		Type: ffRumble,
+		ID:   r.effectID,
+	}
+	e.Replay.Length = uint16(duration / time.Millisecond)
+	e.StrongMagnitude = uint16(clamp01(strongMagnitude) * 0xffff)
+	e.WeakMagnitude = uint16(clamp01(weakMagnitude) * 0xffff)
+
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(r.fd), eviocsff, uintptr(unsafe.Pointer(&e))); errno != 0 {
+		return
+	}
+	r.effectID = e.ID
+	r.hasEffect = true
+
+	play := inputEvent{Type: evFF, Code: uint16(e.ID), Value: 1}
+	unix.Write(r.fd, (*(*[unsafe.Sizeof(inputEvent{})]byte)(unsafe.Pointer(&play)))[:])
+}
+
+func (r *linuxRumbleEffect) stop() {
+	if !r.hasEffect {
+		return
+	}
+	stop := inputEvent{Type: evFF, Code: uint16(r.effectID), Value: 0}
+	unix.Write(r.fd, (*(*[unsafe.Sizeof(inputEvent{})]byte)(unsafe.Pointer(&stop)))[:])
+}
+
+func (r *linuxRumbleEffect) close() error {
+	r.stop()
+	if r.hasEffect {
+		unix.Syscall(unix.SYS_IOCTL, uintptr(r.fd), eviocrmff, uintptr(r.effectID))
+	}
+	return unix.Close(r.fd)
+}
@@ -0,0 +1,108 @@
+// Copyright 2020 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build windows
+
+package glfw
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	xinput1_4          = syscall.NewLazyDLL("xinput1_4.dll")
+	procXInputSetState = xinput1_4.NewProc("XInputSetState")
+)
+
+type xinputVibration struct {
+	LeftMotorSpeed  uint16
+	RightMotorSpeed uint16
+}
+
+type windowsRumbleEffect struct {
+	userIndex uint32
+
+	// generation is bumped on every vibrate call so a stale pending stop
+	// timer from an earlier call can tell it's no longer current and
+	// avoid cutting a newer, still-playing effect short.
+	generation uint32
+}
+
+func openRumbleEffect(id int, guid string) (rumbleEffect, error) {
+	// GLFW doesn't expose the XInput user index a joystick was enumerated
+	// under, so it's recovered heuristically from the trailing digit GLFW
+	// appends to the GUID of XInput-backed devices (the same trick GLFW's
+	// own XInput backend uses internally).
+	idx, err := xinputUserIndexFromGUID(guid)
+	if err != nil {
+		return nil, err
+	}
+	return &windowsRumbleEffect{userIndex: idx}, nil
+}
+
+func xinputUserIndexFromGUID(guid string) (uint32, error) {
+	if len(guid) == 0 {
+		return 0, fmt.Errorf("glfw: empty joystick guid")
+	}
+	last := guid[len(guid)-1:]
+	if !strings.Contains("0123", last) {
+		return 0, fmt.Errorf("glfw: guid %q doesn't look like an XInput device", guid)
+	}
+	n, err := strconv.Atoi(last)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(n), nil
+}
+
+func (r *windowsRumbleEffect) vibrate(duration time.Duration, strongMagnitude, weakMagnitude float64) {
+	gen := atomic.AddUint32(&r.generation, 1)
+
+	if duration <= 0 {
+		r.stop()
+		return
+	}
+
+	v := xinputVibration{
+		LeftMotorSpeed:  uint16(clamp01(strongMagnitude) * 0xffff),
+		RightMotorSpeed: uint16(clamp01(weakMagnitude) * 0xffff),
+	}
+	procXInputSetState.Call(uintptr(r.userIndex), uintptr(unsafe.Pointer(&v)))
+
+	go func() {
+		time.Sleep(duration)
+		if atomic.LoadUint32(&r.generation) != gen {
+			// A later vibrate call has already superseded this one;
+			// stopping now would cut its effect short.
+			return
+		}
+		r.stop()
+	}()
+}
+
+func (r *windowsRumbleEffect) stop() {
+	stop := xinputVibration{}
+	procXInputSetState.Call(uintptr(r.userIndex), uintptr(unsafe.Pointer(&stop)))
+}
+
+func (r *windowsRumbleEffect) close() error {
+	r.stop()
+	return nil
+}
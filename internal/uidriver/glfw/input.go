@@ -21,9 +21,11 @@ package glfw
 
 import (
 	"sync"
+	"time"
 	"unicode"
 
 	"github.com/hajimehoshi/ebiten/internal/driver"
+	"github.com/hajimehoshi/ebiten/internal/gamepaddb"
 	"github.com/hajimehoshi/ebiten/internal/glfw"
 )
 
@@ -35,20 +37,45 @@ type gamePad struct {
 	axes          [16]float64
 	buttonNum     int
 	buttonPressed [256]bool
+	hatsNum       int
+	hats          [16]int
+	rumble        rumbleEffect
 }
 
 type Input struct {
-	keyPressed         map[glfw.Key]bool
-	mouseButtonPressed map[glfw.MouseButton]bool
-	onceCallback       sync.Once
-	scrollX            float64
-	scrollY            float64
-	cursorX            int
-	cursorY            int
-	gamepads           [16]gamePad
-	touches            map[int]pos // This is not updated until GLFW 3.3 is available (#417)
-	runeBuffer         []rune
-	ui                 *UserInterface
+	keyPressed          map[glfw.Key]bool
+	mouseButtonPressed  map[glfw.MouseButton]bool
+	onceCallback        sync.Once
+	scrollX             float64
+	scrollY             float64
+	cursorX             int
+	cursorY             int
+	gamepads            [16]gamePad
+	touches             map[int]pos // This is not updated until GLFW 3.3 is available (#417)
+	runeBuffer          []rune
+	droppedFiles        []string
+	mouseDeltaX         float64
+	mouseDeltaY         float64
+	hasLastMousePos     bool
+	lastMouseX          float64
+	lastMouseY          float64
+	mouseSamples        []MouseSample
+	committedText       []rune
+	compositionText     string
+	compositionSelStart int
+	compositionSelEnd   int
+	textInput           textInputBackend
+	ui                  *UserInterface
+}
+
+// MouseSample is one raw cursor position reported by the OS, timestamped at
+// the moment the callback delivered it. MousePositionHistory returns every
+// sample seen since the last ResetForFrame, so gesture recognizers and
+// drawing tools can reconstruct motion finer than the game's tick rate.
+type MouseSample struct {
+	X float64
+	Y float64
+	T time.Time
 }
 
 type pos struct {
@@ -132,6 +159,48 @@ func (i *Input) IsGamepadButtonPressed(id int, button driver.GamepadButton) bool
 	return i.gamepads[id].buttonPressed[button]
 }
 
+// IsStandardGamepadLayoutAvailable reports whether the gamepad id has a
+// known mapping to the standard SDL/W3C GameController layout, either from
+// the bundled mapping database or one registered via
+// UpdateStandardGamepadLayoutMappings. It returns false for gamepads only
+// covered by the heuristic fallback mapping.
+func (i *Input) IsStandardGamepadLayoutAvailable(id int) bool {
+	i.ui.m.RLock()
+	defer i.ui.m.RUnlock()
+	if len(i.gamepads) <= id || !i.gamepads[id].valid {
+		return false
+	}
+	return gamepaddb.HasStandardLayoutMapping(i.gamepads[id].guid)
+}
+
+func (i *Input) IsStandardGamepadButtonPressed(id int, button driver.StandardGamepadButton) bool {
+	i.ui.m.RLock()
+	defer i.ui.m.RUnlock()
+	if len(i.gamepads) <= id || !i.gamepads[id].valid {
+		return false
+	}
+	g := &i.gamepads[id]
+	return gamepaddb.StandardButtonValue(g.guid, button, g.axes[:g.axisNum], g.buttonPressed[:g.buttonNum], g.hats[:g.hatsNum])
+}
+
+func (i *Input) StandardGamepadAxis(id int, axis driver.StandardGamepadAxis) float64 {
+	i.ui.m.RLock()
+	defer i.ui.m.RUnlock()
+	if len(i.gamepads) <= id || !i.gamepads[id].valid {
+		return 0
+	}
+	g := &i.gamepads[id]
+	return gamepaddb.StandardAxisValue(g.guid, axis, g.axes[:g.axisNum], g.buttonPressed[:g.buttonNum], g.hats[:g.hatsNum])
+}
+
+// UpdateStandardGamepadLayoutMappings registers additional SDL
+// GameControllerDB-formatted mappings on top of the bundled database, so
+// that applications can ship mappings for controllers not yet known to
+// ebiten.
+func (i *Input) UpdateStandardGamepadLayoutMappings(mappingsCSV string) error {
+	return gamepaddb.Update(mappingsCSV)
+}
+
 func (i *Input) TouchIDs() []int {
 	i.ui.m.RLock()
 	defer i.ui.m.RUnlock()
@@ -172,11 +241,46 @@ func (i *Input) RuneBuffer() []rune {
 	return i.runeBuffer
 }
 
+// ClipboardText returns the text currently on the system clipboard.
+func (i *Input) ClipboardText() (string, error) {
+	i.ui.m.RLock()
+	defer i.ui.m.RUnlock()
+	// GLFW doesn't report why reading the clipboard might fail, so the
+	// error return exists only to match platforms where it can.
+	return i.ui.window.GetClipboardString(), nil
+}
+
+// SetClipboardText replaces the contents of the system clipboard with text.
+func (i *Input) SetClipboardText(text string) error {
+	i.ui.m.RLock()
+	defer i.ui.m.RUnlock()
+	i.ui.window.SetClipboardString(text)
+	return nil
+}
+
+// DroppedFiles returns the paths of the files dropped onto the window since
+// the last ResetForFrame call, or nil if none were dropped.
+func (i *Input) DroppedFiles() []string {
+	i.ui.m.RLock()
+	defer i.ui.m.RUnlock()
+	return i.droppedFiles
+}
+
+func (i *Input) setDroppedFiles(names []string) {
+	i.ui.m.Lock()
+	i.droppedFiles = append(i.droppedFiles, names...)
+	i.ui.m.Unlock()
+}
+
 func (i *Input) ResetForFrame() {
 	i.ui.m.RLock()
 	defer i.ui.m.RUnlock()
 	i.runeBuffer = i.runeBuffer[:0]
+	i.committedText = i.committedText[:0]
+	i.droppedFiles = nil
 	i.scrollX, i.scrollY = 0, 0
+	i.mouseDeltaX, i.mouseDeltaY = 0, 0
+	i.mouseSamples = i.mouseSamples[:0]
 }
 
 func (i *Input) IsKeyPressed(key driver.Key) bool {
@@ -219,6 +323,42 @@ func (i *Input) Wheel() (xoff, yoff float64) {
 	return i.scrollX, i.scrollY
 }
 
+// MouseDelta returns the sub-frame raw mouse motion accumulated since the
+// last ResetForFrame, in the OS's raw motion units (unscaled and
+// unaccelerated where the platform supports it).
+func (i *Input) MouseDelta() (dx, dy float64) {
+	i.ui.m.RLock()
+	defer i.ui.m.RUnlock()
+	return i.mouseDeltaX, i.mouseDeltaY
+}
+
+// MousePositionHistory returns every raw cursor position the OS delivered
+// since the last ResetForFrame, oldest first.
+func (i *Input) MousePositionHistory() []MouseSample {
+	i.ui.m.RLock()
+	defer i.ui.m.RUnlock()
+	return i.mouseSamples
+}
+
+// SetCursorMode sets how the cursor behaves relative to the window:
+// Normal shows and frees it, Hidden hides it over the window but leaves it
+// free, and Captured hides it and locks it to the window so it can move
+// without bound (the mode FPS-style camera controls want).
+func (i *Input) SetCursorMode(mode driver.CursorMode) {
+	i.ui.m.RLock()
+	window := i.ui.window
+	i.ui.m.RUnlock()
+
+	switch mode {
+	case driver.CursorModeNormal:
+		window.SetInputMode(glfw.Cursor, glfw.CursorNormal)
+	case driver.CursorModeHidden:
+		window.SetInputMode(glfw.Cursor, glfw.CursorHidden)
+	case driver.CursorModeCaptured:
+		window.SetInputMode(glfw.Cursor, glfw.CursorDisabled)
+	}
+}
+
 var glfwMouseButtonToMouseButton = map[glfw.MouseButton]driver.MouseButton{
 	glfw.MouseButtonLeft:   driver.MouseButtonLeft,
 	glfw.MouseButtonRight:  driver.MouseButtonRight,
@@ -231,6 +371,8 @@ func (i *Input) appendRuneBuffer(char rune) {
 	}
 	i.ui.m.Lock()
 	i.runeBuffer = append(i.runeBuffer, char)
+	i.committedText = append(i.committedText, char)
+	i.compositionText, i.compositionSelStart, i.compositionSelEnd = "", 0, 0
 	i.ui.m.Unlock()
 }
 
@@ -241,6 +383,18 @@ func (i *Input) setWheel(xoff, yoff float64) {
 	i.ui.m.Unlock()
 }
 
+func (i *Input) addMouseSample(x, y float64) {
+	i.ui.m.Lock()
+	defer i.ui.m.Unlock()
+	if i.hasLastMousePos {
+		i.mouseDeltaX += x - i.lastMouseX
+		i.mouseDeltaY += y - i.lastMouseY
+	}
+	i.lastMouseX, i.lastMouseY = x, y
+	i.hasLastMousePos = true
+	i.mouseSamples = append(i.mouseSamples, MouseSample{X: x, Y: y, T: time.Now()})
+}
+
 func (i *Input) update(window *glfw.Window, scale float64) {
 	i.ui.m.Lock()
 	defer i.ui.m.Unlock()
@@ -252,6 +406,15 @@ func (i *Input) update(window *glfw.Window, scale float64) {
 		window.SetScrollCallback(func(w *glfw.Window, xoff float64, yoff float64) {
 			i.setWheel(xoff, yoff)
 		})
+		window.SetDropCallback(func(w *glfw.Window, names []string) {
+			i.setDroppedFiles(names)
+		})
+		window.SetCursorPosCallback(func(w *glfw.Window, x float64, y float64) {
+			i.addMouseSample(x, y)
+		})
+		if glfw.RawMouseMotionSupported() {
+			window.SetInputMode(glfw.RawMouseMotion, glfw.True)
+		}
 	})
 	if i.keyPressed == nil {
 		i.keyPressed = map[glfw.Key]bool{}
@@ -269,8 +432,11 @@ func (i *Input) update(window *glfw.Window, scale float64) {
 	i.cursorX = int(x / scale)
 	i.cursorY = int(y / scale)
 	for id := glfw.Joystick(0); id < glfw.Joystick(len(i.gamepads)); id++ {
-		i.gamepads[id].valid = false
 		if !glfw.JoystickPresent(id) {
+			if i.gamepads[id].valid {
+				i.gamepads[id].closeRumble()
+			}
+			i.gamepads[id].valid = false
 			continue
 		}
 		i.gamepads[id].valid = true
@@ -295,5 +461,15 @@ func (i *Input) update(window *glfw.Window, scale float64) {
 			}
 			i.gamepads[id].buttonPressed[b] = glfw.Action(buttons[b]) == glfw.Press
 		}
+
+		hats := glfw.GetJoystickHats(id)
+		i.gamepads[id].hatsNum = len(hats)
+		for h := 0; h < len(i.gamepads[id].hats); h++ {
+			if len(hats) <= h {
+				i.gamepads[id].hats[h] = 0
+				continue
+			}
+			i.gamepads[id].hats[h] = int(hats[h])
+		}
 	}
 }
@@ -0,0 +1,123 @@
+// Copyright 2020 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build darwin freebsd linux windows
+// +build !js
+// +build !android
+// +build !ios
+
+package glfw
+
+import (
+	"image"
+
+	"github.com/hajimehoshi/ebiten/internal/glfw"
+)
+
+// textInputBackend drives the OS IME so that CJK/emoji composition is
+// visible while it's being typed, not just once it's committed. It's
+// implemented either on top of GLFW's own preedit callbacks (GLFW 3.4+) or,
+// where those aren't available, directly against the platform's native
+// input method API (see textinput_*.go).
+type textInputBackend interface {
+	start(rect image.Rectangle)
+	stop()
+}
+
+// StartTextInput tells the OS IME to begin composing text for this window,
+// placing its candidate window near rect (in ebiten screen coordinates).
+func (i *Input) StartTextInput(rect image.Rectangle) {
+	i.ui.m.Lock()
+	defer i.ui.m.Unlock()
+	if i.textInput == nil {
+		i.textInput = newTextInputBackend(i.ui.window, i)
+	}
+	i.textInput.start(rect)
+}
+
+// StopTextInput tells the OS IME that text composition is no longer
+// wanted, e.g. because the focused widget lost focus.
+func (i *Input) StopTextInput() {
+	i.ui.m.Lock()
+	defer i.ui.m.Unlock()
+	if i.textInput == nil {
+		return
+	}
+	i.textInput.stop()
+}
+
+// CompositionText returns the IME's current pre-edit (not yet committed)
+// string, along with the selection range within it that the IME wants
+// highlighted. Both are zero values when nothing is being composed. Only
+// the macOS fallback reports a real range; the GLFW preedit, X11 and
+// Windows backends report the caret, with selStart == selEnd.
+func (i *Input) CompositionText() (text string, selStart, selEnd int) {
+	i.ui.m.RLock()
+	defer i.ui.m.RUnlock()
+	return i.compositionText, i.compositionSelStart, i.compositionSelEnd
+}
+
+// CommittedText returns the characters the IME (or a plain keyboard) has
+// finalized since the last ResetForFrame.
+func (i *Input) CommittedText() []rune {
+	i.ui.m.RLock()
+	defer i.ui.m.RUnlock()
+	return i.committedText
+}
+
+func (i *Input) setComposition(text string, selStart, selEnd int) {
+	i.ui.m.Lock()
+	i.compositionText, i.compositionSelStart, i.compositionSelEnd = text, selStart, selEnd
+	i.ui.m.Unlock()
+}
+
+// newTextInputBackend prefers GLFW's own preedit support, available since
+// GLFW 3.4, and otherwise falls back to platform-native code.
+func newTextInputBackend(window *glfw.Window, input *Input) textInputBackend {
+	if glfw.PreeditSupported() {
+		return newGLFWPreeditBackend(window, input)
+	}
+	return newNativeTextInputBackend(window, input)
+}
+
+type glfwPreeditBackend struct {
+	window *glfw.Window
+	input  *Input
+}
+
+func newGLFWPreeditBackend(window *glfw.Window, input *Input) *glfwPreeditBackend {
+	b := &glfwPreeditBackend{
+		window: window,
+		input:  input,
+	}
+	window.SetPreeditCallback(func(w *glfw.Window, preeditString []rune, blockSizes []int, focusedBlock int, caret int) {
+		b.input.setComposition(string(preeditString), caret, caret)
+	})
+	window.SetPreeditCandidateCallback(func(w *glfw.Window, candidatesCount int, selectedIndex int, pageStart int, pageSize int) {
+		// The candidate list itself isn't surfaced through CompositionText;
+		// only the pre-edit string and caret are. Games that want to draw
+		// the candidate window themselves can extend this callback later.
+	})
+	return b
+}
+
+func (b *glfwPreeditBackend) start(rect image.Rectangle) {
+	b.window.SetPreeditCursorRectangle(rect.Min.X, rect.Min.Y, rect.Dx(), rect.Dy())
+	b.window.SetIMEStatus(true)
+}
+
+func (b *glfwPreeditBackend) stop() {
+	b.window.SetIMEStatus(false)
+	b.input.setComposition("", 0, 0)
+}
@@ -0,0 +1,96 @@
+// Copyright 2020 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build darwin,!ios
+
+// Fallback IME support for GLFW builds older than 3.4 on macOS, which do
+// not expose preedit callbacks. It swaps in a tiny NSView subclass that
+// implements NSTextInputClient on top of the GLFW-owned content view, so
+// that Cocoa routes IME composition events to it.
+package glfw
+
+// #cgo LDFLAGS: -framework Cocoa
+//
+// #include <stdlib.h>
+//
+// typedef struct { int location; int length; } ebitenRange;
+// void* ebitenInstallTextInputView(void* nsWindow, void* goBackend);
+// void ebitenRemoveTextInputView(void* view);
+// void ebitenSetMarkedTextOrigin(void* view, double x, double y);
+//
+// extern void ebitenSetMarkedText(void *goBackend, char *text, int selStart, int selEnd);
+// extern void ebitenUnmarkText(void *goBackend);
+// extern void ebitenInsertText(void *goBackend, char *text);
+import "C"
+
+import (
+	"image"
+	"unsafe"
+
+	"github.com/hajimehoshi/ebiten/internal/glfw"
+)
+
+var darwinTextInputBackends = map[unsafe.Pointer]*darwinTextInputBackend{}
+
+type darwinTextInputBackend struct {
+	view  unsafe.Pointer
+	input *Input
+}
+
+func newNativeTextInputBackend(window *glfw.Window, input *Input) textInputBackend {
+	nsWindow := window.GetCocoaWindow()
+	b := &darwinTextInputBackend{input: input}
+	b.view = C.ebitenInstallTextInputView(nsWindow, unsafe.Pointer(b))
+	darwinTextInputBackends[b.view] = b
+	return b
+}
+
+func (b *darwinTextInputBackend) start(rect image.Rectangle) {
+	C.ebitenSetMarkedTextOrigin(b.view, C.double(rect.Min.X), C.double(rect.Min.Y))
+}
+
+func (b *darwinTextInputBackend) stop() {
+	delete(darwinTextInputBackends, b.view)
+	C.ebitenRemoveTextInputView(b.view)
+	b.input.setComposition("", 0, 0)
+}
+
+//export ebitenSetMarkedText
+func ebitenSetMarkedText(goBackend unsafe.Pointer, text *C.char, selStart, selEnd C.int) {
+	b := darwinTextInputBackends[goBackend]
+	if b == nil {
+		return
+	}
+	b.input.setComposition(C.GoString(text), int(selStart), int(selEnd))
+}
+
+//export ebitenUnmarkText
+func ebitenUnmarkText(goBackend unsafe.Pointer) {
+	b := darwinTextInputBackends[goBackend]
+	if b == nil {
+		return
+	}
+	b.input.setComposition("", 0, 0)
+}
+
+//export ebitenInsertText
+func ebitenInsertText(goBackend unsafe.Pointer, text *C.char) {
+	b := darwinTextInputBackends[goBackend]
+	if b == nil {
+		return
+	}
+	for _, r := range C.GoString(text) {
+		b.input.appendRuneBuffer(r)
+	}
+}
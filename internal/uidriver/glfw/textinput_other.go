@@ -0,0 +1,158 @@
+// Copyright 2020 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux freebsd
+// +build !android
+
+// Fallback IME support for GLFW builds older than 3.4 on X11, which do not
+// expose preedit callbacks. It opens an XIM input method directly against
+// the window GLFW created and receives the composition through an
+// on-the-spot input context (XIMPreeditCallbacks), so that it surfaces
+// through CompositionText.
+package glfw
+
+// #cgo LDFLAGS: -lX11
+//
+// #include <X11/Xlib.h>
+// #include <X11/Xutil.h>
+// #include <stdlib.h>
+//
+// extern void ebitenPreeditStartCallback(XIC xic, XPointer clientData, XPointer callData);
+// extern void ebitenPreeditDoneCallback(XIC xic, XPointer clientData, XPointer callData);
+// extern void ebitenPreeditDrawCallback(XIC xic, XPointer clientData, XIMPreeditDrawCallbackStruct *callData);
+//
+// static XIC ebiten_xim_create_ic(XIM xim, Window win, XPointer clientData) {
+//   XVaNestedList preeditAttr = XVaCreateNestedList(0,
+//     XNPreeditStartCallback, &(XIMCallback){clientData, (XIMProc)ebitenPreeditStartCallback},
+//     XNPreeditDoneCallback, &(XIMCallback){clientData, (XIMProc)ebitenPreeditDoneCallback},
+//     XNPreeditDrawCallback, &(XIMCallback){clientData, (XIMProc)ebitenPreeditDrawCallback},
+//     NULL);
+//   XIC xic = XCreateIC(xim,
+//     XNInputStyle, XIMPreeditCallbacks | XIMStatusNothing,
+//     XNClientWindow, win,
+//     XNFocusWindow, win,
+//     XNPreeditAttributes, preeditAttr,
+//     NULL);
+//   XFree(preeditAttr);
+//   return xic;
+// }
+//
+// static void ebiten_set_spot_location(XIC xic, short x, short y) {
+//   XPoint spot = {x, y};
+//   XVaNestedList attr = XVaCreateNestedList(0, XNSpotLocation, &spot, NULL);
+//   XSetICValues(xic, XNPreeditAttributes, attr, NULL);
+//   XFree(attr);
+// }
+import "C"
+
+import (
+	"image"
+	"unsafe"
+
+	"github.com/hajimehoshi/ebiten/internal/glfw"
+)
+
+var xicBackends = map[C.XIC]*x11TextInputBackend{}
+
+type x11TextInputBackend struct {
+	display *C.Display
+	xim     C.XIM
+	xic     C.XIC
+	input   *Input
+	preedit []rune
+}
+
+func newNativeTextInputBackend(window *glfw.Window, input *Input) textInputBackend {
+	display := (*C.Display)(window.GetX11Display())
+	win := C.Window(window.GetX11Window())
+
+	xim := C.XOpenIM(display, nil, nil, nil)
+	if xim == nil {
+		// No input method server is running (e.g. no fcitx/ibus); leave
+		// composition permanently empty rather than failing StartTextInput.
+		return &x11TextInputBackend{input: input}
+	}
+
+	b := &x11TextInputBackend{display: display, xim: xim, input: input}
+	b.xic = C.ebiten_xim_create_ic(xim, win, C.XPointer(unsafe.Pointer(b)))
+	xicBackends[b.xic] = b
+	return b
+}
+
+func (b *x11TextInputBackend) start(rect image.Rectangle) {
+	if b.xic == nil {
+		return
+	}
+	C.ebiten_set_spot_location(b.xic, C.short(rect.Min.X), C.short(rect.Min.Y))
+	C.XSetICFocus(b.xic)
+}
+
+func (b *x11TextInputBackend) stop() {
+	if b.xic != nil {
+		C.XUnsetICFocus(b.xic)
+	}
+	b.input.setComposition("", 0, 0)
+}
+
+//export ebitenPreeditStartCallback
+func ebitenPreeditStartCallback(xic C.XIC, clientData C.XPointer, callData C.XPointer) {
+	if b := xicBackends[xic]; b != nil {
+		b.preedit = b.preedit[:0]
+		b.input.setComposition("", 0, 0)
+	}
+}
+
+//export ebitenPreeditDoneCallback
+func ebitenPreeditDoneCallback(xic C.XIC, clientData C.XPointer, callData C.XPointer) {
+	if b := xicBackends[xic]; b != nil {
+		b.preedit = b.preedit[:0]
+		b.input.setComposition("", 0, 0)
+	}
+}
+
+//export ebitenPreeditDrawCallback
+func ebitenPreeditDrawCallback(xic C.XIC, clientData C.XPointer, callData *C.XIMPreeditDrawCallbackStruct) {
+	b := xicBackends[xic]
+	if b == nil || callData == nil {
+		return
+	}
+
+	chg := callData.text
+	deleteStart := int(callData.chg_first)
+	deleteLen := int(callData.chg_length)
+	if deleteStart >= 0 && deleteStart+deleteLen <= len(b.preedit) {
+		tail := append([]rune{}, b.preedit[deleteStart+deleteLen:]...)
+		b.preedit = append(b.preedit[:deleteStart], tail...)
+	}
+
+	// chg.string is a C union of char* and wchar_t*; cgo exposes it as an
+	// opaque byte array, so the wide-char pointer is read out manually.
+	if chg != nil {
+		wcharPtr := *(*unsafe.Pointer)(unsafe.Pointer(&chg.string))
+		if wcharPtr != nil {
+			n := int(chg.length)
+			inserted := make([]rune, n)
+			ptr := (*[1 << 20]C.wchar_t)(wcharPtr)
+			for i := 0; i < n; i++ {
+				inserted[i] = rune(ptr[i])
+			}
+			head := append([]rune{}, b.preedit[:deleteStart]...)
+			head = append(head, inserted...)
+			b.preedit = append(head, b.preedit[deleteStart:]...)
+		}
+	}
+
+	caret := int(callData.caret)
+	b.input.setComposition(string(b.preedit), caret, caret)
+}
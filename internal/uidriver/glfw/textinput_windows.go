@@ -0,0 +1,124 @@
+// Copyright 2020 Hajime Hoshi
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build windows
+
+package glfw
+
+import (
+	"image"
+	"syscall"
+	"unicode/utf16"
+	"unsafe"
+
+	"github.com/hajimehoshi/ebiten/internal/glfw"
+)
+
+// Fallback IME support for GLFW builds older than 3.4, which don't expose
+// preedit callbacks. It reads the composition string directly out of
+// WM_IME_COMPOSITION via the IMM32 API, subclassing the GLFW-owned window
+// procedure to observe the message.
+var (
+	imm32                        = syscall.NewLazyDLL("imm32.dll")
+	procImmGetContext            = imm32.NewProc("ImmGetContext")
+	procImmReleaseContext        = imm32.NewProc("ImmReleaseContext")
+	procImmGetCompositionStringW = imm32.NewProc("ImmGetCompositionStringW")
+	procImmSetCandidateWindow    = imm32.NewProc("ImmSetCandidateWindow")
+
+	user32                = syscall.NewLazyDLL("user32.dll")
+	procSetWindowLongPtrW = user32.NewProc("SetWindowLongPtrW")
+	procCallWindowProcW   = user32.NewProc("CallWindowProcW")
+)
+
+const (
+	wmImeComposition = 0x010F
+	gcsCompStr       = 0x0008
+	gcsCursorPos     = 0x0080
+	gwlpWndProc      = ^uintptr(3) // GWLP_WNDPROC (-4), as its uintptr bit pattern
+)
+
+type candidateForm struct {
+	dwIndex      uint32
+	dwStyle      uint32
+	ptCurrentPos [2]int32
+	rcArea       [4]int32
+}
+
+type windowsTextInputBackend struct {
+	hwnd        uintptr
+	input       *Input
+	prevWndProc uintptr
+}
+
+func newNativeTextInputBackend(window *glfw.Window, input *Input) textInputBackend {
+	hwnd := window.GetWin32Window()
+	b := &windowsTextInputBackend{hwnd: hwnd, input: input}
+	b.prevWndProc, _, _ = procSetWindowLongPtrW.Call(hwnd, gwlpWndProc, syscall.NewCallback(b.wndProc))
+	return b
+}
+
+func (b *windowsTextInputBackend) wndProc(hwnd uintptr, msg uint32, wparam, lparam uintptr) uintptr {
+	if msg == wmImeComposition && lparam&gcsCompStr != 0 {
+		b.readComposition()
+	}
+	r, _, _ := procCallWindowProcW.Call(b.prevWndProc, hwnd, uintptr(msg), wparam, lparam)
+	return r
+}
+
+func (b *windowsTextInputBackend) readComposition() {
+	himc, _, _ := procImmGetContext.Call(b.hwnd)
+	if himc == 0 {
+		return
+	}
+	defer procImmReleaseContext.Call(b.hwnd, himc)
+
+	n, _, _ := procImmGetCompositionStringW.Call(himc, gcsCompStr, 0, 0)
+	size := int32(n)
+	if size <= 0 {
+		b.input.setComposition("", 0, 0)
+		return
+	}
+	buf := make([]uint16, size/2)
+	procImmGetCompositionStringW.Call(himc, gcsCompStr, uintptr(unsafe.Pointer(&buf[0])), uintptr(size))
+	text := utf16.Decode(buf)
+
+	cursor, _, _ := procImmGetCompositionStringW.Call(himc, gcsCursorPos, 0, 0)
+	b.input.setComposition(string(text), int(cursor), int(cursor))
+}
+
+func (b *windowsTextInputBackend) start(rect image.Rectangle) {
+	himc, _, _ := procImmGetContext.Call(b.hwnd)
+	if himc == 0 {
+		return
+	}
+	defer procImmReleaseContext.Call(b.hwnd, himc)
+
+	form := candidateForm{
+		dwIndex: 0,
+		dwStyle: 0x0040, // CFS_CANDIDATEPOS
+		ptCurrentPos: [2]int32{
+			int32(rect.Min.X),
+			int32(rect.Min.Y),
+		},
+		rcArea: [4]int32{
+			int32(rect.Min.X), int32(rect.Min.Y),
+			int32(rect.Max.X), int32(rect.Max.Y),
+		},
+	}
+	procImmSetCandidateWindow.Call(himc, uintptr(unsafe.Pointer(&form)))
+}
+
+func (b *windowsTextInputBackend) stop() {
+	b.input.setComposition("", 0, 0)
+}